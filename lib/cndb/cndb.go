@@ -0,0 +1,73 @@
+// Package cndb normalizes the Chinese-language country and province names
+// used by domestic IP databases (ip2region, QQWry) into ISO 3166-1/3166-2
+// codes, so their entries line up with the rest of this tool's country-code
+// keyed entries.
+package cndb
+
+import "strings"
+
+// CountryToISO maps the common Chinese country/region names found in
+// ip2region and QQWry records to ISO 3166-1 alpha-2 codes. It is not
+// exhaustive; unmapped names should be skipped by callers rather than
+// guessed at.
+var CountryToISO = map[string]string{
+	"中国":   "CN",
+	"香港":   "HK",
+	"澳门":   "MO",
+	"台湾":   "TW",
+	"美国":   "US",
+	"日本":   "JP",
+	"韩国":   "KR",
+	"新加坡":  "SG",
+	"英国":   "GB",
+	"法国":   "FR",
+	"德国":   "DE",
+	"俄罗斯":  "RU",
+	"加拿大":  "CA",
+	"澳大利亚": "AU",
+	"印度":   "IN",
+	"荷兰":   "NL",
+}
+
+// ProvinceToISO maps common mainland China province/municipality names to
+// their ISO 3166-2:CN subdivision suffix (without the "CN-" prefix).
+var ProvinceToISO = map[string]string{
+	"北京": "BJ",
+	"上海": "SH",
+	"天津": "TJ",
+	"重庆": "CQ",
+	"广东": "GD",
+	"江苏": "JS",
+	"浙江": "ZJ",
+	"四川": "SC",
+	"湖北": "HB",
+	"湖南": "HN",
+	"福建": "FJ",
+	"山东": "SD",
+	"河南": "HA",
+	"河北": "HE",
+	"陕西": "SN",
+	"辽宁": "LN",
+	"云南": "YN",
+}
+
+// NormalizeCountry trims a raw database country/area string and returns its
+// ISO 3166-1 alpha-2 code, and whether a mapping was found.
+func NormalizeCountry(raw string) (string, bool) {
+	name := strings.TrimSpace(raw)
+	code, ok := CountryToISO[name]
+	return code, ok
+}
+
+// NormalizeProvince trims a raw database province/area string and returns
+// its ISO 3166-2:CN subdivision code (e.g. "CN-SH"), and whether a mapping
+// was found. name should be the province portion only, without "省"/"市" etc.
+func NormalizeProvince(raw string) (string, bool) {
+	name := strings.TrimSpace(raw)
+	for provinceName, code := range ProvinceToISO {
+		if strings.Contains(name, provinceName) {
+			return "CN-" + code, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,202 @@
+// Package fetch provides a single URI-reading entry point for input
+// converters that need to consume both local files and remote http(s)
+// resources. Remote resources are cached on disk and revalidated with
+// conditional GET, and gzip/zstd content is decompressed transparently.
+package fetch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Options configures a single fetch of URI, which may be a local filesystem
+// path or an http(s):// URL.
+type Options struct {
+	URI      string
+	Timeout  time.Duration
+	CacheTTL time.Duration
+	Headers  map[string]string
+}
+
+// Fetch returns the contents of opts.URI. Local paths are read directly.
+// Remote URLs are served from the on-disk cache when still within
+// opts.CacheTTL, otherwise refreshed with a conditional GET
+// (If-Modified-Since/ETag) against $XDG_CACHE_HOME/geoip, falling back to a
+// stale cache entry if the request itself fails.
+func Fetch(opts Options) ([]byte, error) {
+	if !IsRemote(opts.URI) {
+		return os.ReadFile(opts.URI)
+	}
+	return fetchRemote(opts)
+}
+
+// IsRemote reports whether uri is an http(s):// URL rather than a local
+// filesystem path. Converters that read a file directly instead of going
+// through Fetch (e.g. to stream rather than buffer it) can use this to
+// reject remote URIs with a clear error instead of failing with a
+// confusing "no such file or directory".
+func IsRemote(uri string) bool {
+	return strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://")
+}
+
+type cacheMeta struct {
+	ETag         string    `json:"etag"`
+	LastModified string    `json:"lastModified"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+}
+
+func fetchRemote(opts Options) ([]byte, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("fetch: failed to resolve cache dir: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("fetch: failed to create cache dir: %v", err)
+	}
+
+	key := cacheKey(opts.URI)
+	contentPath := filepath.Join(dir, key+".bin")
+	metaPath := filepath.Join(dir, key+".meta.json")
+
+	meta, hasMeta := readMeta(metaPath)
+	if hasMeta && opts.CacheTTL > 0 && time.Since(meta.FetchedAt) < opts.CacheTTL {
+		if content, err := os.ReadFile(contentPath); err == nil {
+			return content, nil
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, opts.URI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch: failed to build request for %s: %v", opts.URI, err)
+	}
+	for k, v := range opts.Headers {
+		req.Header.Set(k, v)
+	}
+	if hasMeta {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if hasMeta {
+			if content, ferr := os.ReadFile(contentPath); ferr == nil {
+				return content, nil
+			}
+		}
+		return nil, fmt.Errorf("fetch: request to %s failed: %v", opts.URI, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		content, err := os.ReadFile(contentPath)
+		if err != nil {
+			return nil, fmt.Errorf("fetch: got 304 from %s but cache is missing: %v", opts.URI, err)
+		}
+		writeMeta(metaPath, cacheMeta{ETag: meta.ETag, LastModified: meta.LastModified, FetchedAt: time.Now()})
+		return content, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch: unexpected status %d from %s", resp.StatusCode, opts.URI)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("fetch: failed to read response body from %s: %v", opts.URI, err)
+	}
+
+	content, err := decompress(resp.Header.Get("Content-Encoding"), opts.URI, body)
+	if err != nil {
+		return nil, fmt.Errorf("fetch: failed to decompress response from %s: %v", opts.URI, err)
+	}
+
+	if err := os.WriteFile(contentPath, content, 0o644); err != nil {
+		return nil, fmt.Errorf("fetch: failed to write cache file: %v", err)
+	}
+	writeMeta(metaPath, cacheMeta{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+	})
+
+	return content, nil
+}
+
+func decompress(contentEncoding, uri string, body []byte) ([]byte, error) {
+	switch {
+	case strings.Contains(contentEncoding, "zstd") || strings.HasSuffix(uri, ".zst"):
+		decoder, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		return io.ReadAll(decoder)
+	case strings.Contains(contentEncoding, "gzip") || strings.HasSuffix(uri, ".gz"):
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	default:
+		return body, nil
+	}
+}
+
+func cacheKey(uri string) string {
+	sum := sha256.Sum256([]byte(uri))
+	return hex.EncodeToString(sum[:])
+}
+
+func cacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "geoip"), nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "geoip"), nil
+}
+
+func readMeta(path string) (cacheMeta, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return cacheMeta{}, false
+	}
+	var meta cacheMeta
+	if err := json.Unmarshal(content, &meta); err != nil {
+		return cacheMeta{}, false
+	}
+	return meta, true
+}
+
+func writeMeta(path string, meta cacheMeta) {
+	content, err := json.Marshal(meta)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(path, content, 0o644)
+}
@@ -0,0 +1,96 @@
+// Package iprange converts inclusive [from, to] IP address ranges, as found
+// in IP2Location BIN rows, ip2region xdb records and QQWry .dat records,
+// into the minimal list of CIDR prefixes that exactly cover the range.
+package iprange
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+)
+
+// Split returns the minimal set of CIDR prefixes covering the inclusive
+// range [from, to]. from and to must belong to the same address family.
+func Split(from, to netip.Addr) ([]netip.Prefix, error) {
+	if from.Is4() != to.Is4() {
+		return nil, fmt.Errorf("iprange: mismatched address families in range %s-%s", from, to)
+	}
+	if to.Less(from) {
+		return nil, fmt.Errorf("iprange: invalid range %s-%s", from, to)
+	}
+
+	bits := 32
+	if from.Is6() {
+		bits = 128
+	}
+
+	cur := addrToInt(from)
+	end := addrToInt(to)
+	one := big.NewInt(1)
+
+	var prefixes []netip.Prefix
+	for cur.Cmp(end) <= 0 {
+		// Number of trailing zero bits in cur, i.e. the largest block size
+		// cur is aligned to. Grow the candidate block size from 1 bit
+		// upward and stop at the first misalignment.
+		trailingZeros := 0
+		for trailingZeros < bits {
+			mask := new(big.Int).Lsh(one, uint(trailingZeros+1))
+			mask.Sub(mask, one)
+			if new(big.Int).And(cur, mask).Sign() != 0 {
+				break
+			}
+			trailingZeros++
+		}
+		alignSize := bits - trailingZeros
+
+		// Largest block that still fits within the remaining range.
+		remaining := new(big.Int).Sub(end, cur)
+		remaining.Add(remaining, one)
+		fitSize := bits - (remaining.BitLen() - 1)
+		if fitSize < 0 {
+			fitSize = 0
+		}
+
+		prefixLen := alignSize
+		if fitSize > prefixLen {
+			prefixLen = fitSize
+		}
+
+		addr, err := intToAddr(cur, from.Is6())
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, prefixLen))
+
+		blockSize := new(big.Int).Lsh(one, uint(bits-prefixLen))
+		cur.Add(cur, blockSize)
+	}
+
+	return prefixes, nil
+}
+
+func addrToInt(addr netip.Addr) *big.Int {
+	return new(big.Int).SetBytes(addr.AsSlice())
+}
+
+func intToAddr(i *big.Int, is6 bool) (netip.Addr, error) {
+	size := 4
+	if is6 {
+		size = 16
+	}
+
+	b := i.Bytes()
+	if len(b) > size {
+		return netip.Addr{}, fmt.Errorf("iprange: integer overflows address size")
+	}
+
+	buf := make([]byte, size)
+	copy(buf[size-len(b):], b)
+
+	addr, ok := netip.AddrFromSlice(buf)
+	if !ok {
+		return netip.Addr{}, fmt.Errorf("iprange: failed to build address from bytes")
+	}
+	return addr, nil
+}
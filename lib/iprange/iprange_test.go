@@ -0,0 +1,78 @@
+package iprange
+
+import (
+	"math/big"
+	"net/netip"
+	"testing"
+)
+
+func TestSplitRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+	}{
+		{"whole IPv4 space", "0.0.0.0", "255.255.255.255"},
+		{"single address", "1.2.3.4", "1.2.3.4"},
+		{"unaligned small range", "1.2.3.4", "1.2.3.20"},
+		{"aligned /24", "10.0.0.0", "10.0.0.255"},
+		{"range spanning octet boundary", "10.0.0.200", "10.0.2.50"},
+		{"IPv6 unaligned range", "2001:db8::1", "2001:db8::100"},
+		// Row boundaries as actually emitted by ip2region xdb and QQWry dat
+		// records, which are almost never aligned to a power-of-two block.
+		{"ip2region xdb row boundary", "36.132.0.1", "36.135.255.255"},
+		{"qqwry dat row boundary", "222.16.10.0", "222.16.31.255"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := netip.MustParseAddr(tt.from)
+			to := netip.MustParseAddr(tt.to)
+
+			prefixes, err := Split(from, to)
+			if err != nil {
+				t.Fatalf("Split(%s, %s): unexpected error: %v", tt.from, tt.to, err)
+			}
+
+			count := new(big.Int)
+			for _, p := range prefixes {
+				if p != p.Masked() {
+					t.Errorf("prefix %s is not in canonical (masked) form", p)
+				}
+
+				lo := p.Addr()
+				hi := lastAddr(p)
+				if lo.Compare(from) < 0 || hi.Compare(to) > 0 {
+					t.Fatalf("prefix %s escapes range %s-%s", p, tt.from, tt.to)
+				}
+
+				blockSize := new(big.Int).Lsh(big.NewInt(1), uint(lo.BitLen()-p.Bits()))
+				count.Add(count, blockSize)
+			}
+
+			want := new(big.Int).Sub(addrToInt(to), addrToInt(from))
+			want.Add(want, big.NewInt(1))
+
+			if count.Cmp(want) != 0 {
+				t.Errorf("Split(%s, %s) covers %s addresses, want %s", tt.from, tt.to, count, want)
+			}
+		})
+	}
+}
+
+// lastAddr returns the last address covered by prefix p.
+func lastAddr(p netip.Prefix) netip.Addr {
+	addr := p.Addr()
+	bytes := addr.AsSlice()
+	hostBits := addr.BitLen() - p.Bits()
+	for i := len(bytes) - 1; hostBits > 0; i-- {
+		if hostBits >= 8 {
+			bytes[i] = 0xff
+			hostBits -= 8
+			continue
+		}
+		bytes[i] |= byte(1<<hostBits) - 1
+		hostBits = 0
+	}
+	out, _ := netip.AddrFromSlice(bytes)
+	return out
+}
@@ -0,0 +1,345 @@
+package ip2location
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/Loyalsoldier/geoip/lib"
+	"github.com/Loyalsoldier/geoip/lib/iprange"
+	ip2location "github.com/ip2location/ip2location-go/v9"
+)
+
+const (
+	TypeIP2LocationBINIn = "ip2locationBIN"
+	DescIP2LocationBINIn = "Convert IP2Location BIN database to other formats"
+)
+
+// Emit level controls how finely grained the produced entries are.
+const (
+	emitLevelCountry = "country"
+	emitLevelRegion  = "region"
+	emitLevelCity    = "city"
+)
+
+func init() {
+	lib.RegisterInputConfigCreator(TypeIP2LocationBINIn, func(action lib.Action, data json.RawMessage) (lib.InputConverter, error) {
+		return newIP2LocationBINIn(action, data)
+	})
+	lib.RegisterInputConverter(TypeIP2LocationBINIn, &IP2LocationBINIn{
+		Description: DescIP2LocationBINIn,
+	})
+}
+
+func newIP2LocationBINIn(action lib.Action, data json.RawMessage) (lib.InputConverter, error) {
+	var tmp struct {
+		URI        string     `json:"uri"`
+		Want       []string   `json:"wantedList"`
+		OnlyIPType lib.IPType `json:"onlyIPType"`
+		EmitLevel  string     `json:"emitLevel"`
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &tmp); err != nil {
+			return nil, err
+		}
+	}
+
+	if tmp.URI == "" {
+		return nil, fmt.Errorf("❌ [type %s | action %s] uri is required", TypeIP2LocationBINIn, action)
+	}
+
+	if tmp.EmitLevel == "" {
+		tmp.EmitLevel = emitLevelCountry
+	}
+	switch tmp.EmitLevel {
+	case emitLevelCountry, emitLevelRegion, emitLevelCity:
+	default:
+		return nil, fmt.Errorf("❌ [type %s | action %s] invalid emitLevel %s", TypeIP2LocationBINIn, action, tmp.EmitLevel)
+	}
+
+	wantList := make(map[string]bool)
+	for _, want := range tmp.Want {
+		if want = strings.ToUpper(strings.TrimSpace(want)); want != "" {
+			wantList[want] = true
+		}
+	}
+
+	return &IP2LocationBINIn{
+		Type:        TypeIP2LocationBINIn,
+		Action:      action,
+		Description: DescIP2LocationBINIn,
+		URI:         tmp.URI,
+		Want:        wantList,
+		OnlyIPType:  tmp.OnlyIPType,
+		EmitLevel:   tmp.EmitLevel,
+	}, nil
+}
+
+// IP2LocationBINIn reads an IP2Location BIN database (DB1/DB3/DB11/DB24,
+// IPv4 or IPv6 variant) and emits one entry per country, or per
+// region/city when EmitLevel asks for finer granularity.
+type IP2LocationBINIn struct {
+	Type        string
+	Action      lib.Action
+	Description string
+	URI         string
+	Want        map[string]bool
+	OnlyIPType  lib.IPType
+	EmitLevel   string
+}
+
+func (b *IP2LocationBINIn) GetType() string {
+	return b.Type
+}
+
+func (b *IP2LocationBINIn) GetAction() lib.Action {
+	return b.Action
+}
+
+func (b *IP2LocationBINIn) GetDescription() string {
+	return b.Description
+}
+
+func (b *IP2LocationBINIn) Input(container lib.Container) (lib.Container, error) {
+	db, err := ip2location.OpenDB(b.URI)
+	if err != nil {
+		return nil, fmt.Errorf("❌ [type %s | action %s] failed to open BIN file: %v", b.Type, b.Action, err)
+	}
+	defer db.Close()
+
+	header, err := readBINHeader(b.URI)
+	if err != nil {
+		return nil, fmt.Errorf("❌ [type %s | action %s] failed to read BIN header: %v", b.Type, b.Action, err)
+	}
+
+	entryMap := make(map[string]*lib.Entry)
+
+	if b.OnlyIPType != lib.IPv6 && header.ipv4Count > 0 {
+		if err := b.walkRecords(db, header, false, entryMap); err != nil {
+			return nil, err
+		}
+	}
+	if b.OnlyIPType != lib.IPv4 && header.ipv6Count > 0 {
+		if err := b.walkRecords(db, header, true, entryMap); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, entry := range entryMap {
+		if len(b.Want) > 0 && !b.Want[name] {
+			continue
+		}
+
+		switch b.Action {
+		case lib.ActionAdd:
+			if err := container.Add(entry); err != nil {
+				return nil, fmt.Errorf("❌ [type %s | action %s] failed to add entry %s: %v", b.Type, b.Action, name, err)
+			}
+		case lib.ActionRemove:
+			var ignoreIPType lib.IgnoreIPOption
+			switch b.OnlyIPType {
+			case lib.IPv4:
+				ignoreIPType = lib.IgnoreIPv6
+			case lib.IPv6:
+				ignoreIPType = lib.IgnoreIPv4
+			}
+			if err := container.Remove(entry, lib.CaseRemoveEntry, ignoreIPType); err != nil {
+				return nil, fmt.Errorf("❌ [type %s | action %s] failed to remove entry %s: %v", b.Type, b.Action, name, err)
+			}
+		}
+	}
+
+	return container, nil
+}
+
+// walkRecords iterates the BIN's record table in file order. Each row only
+// stores the range's starting address; the end address is the next row's
+// start minus one (or the family's maximum address for the last row). This
+// lets the whole file be converted in a single linear pass instead of
+// probing every possible /24.
+func (b *IP2LocationBINIn) walkRecords(db *ip2location.DB, header *binHeader, isIPv6 bool, entryMap map[string]*lib.Entry) error {
+	count := header.ipv4Count
+	baseAddr := header.ipv4BaseAddr
+	addrSize := 4
+	if isIPv6 {
+		count = header.ipv6Count
+		baseAddr = header.ipv6BaseAddr
+		addrSize = 16
+	}
+
+	rowSize := addrSize + (header.columnCount-1)*4
+
+	file, err := os.Open(b.URI)
+	if err != nil {
+		return fmt.Errorf("❌ [type %s | action %s] failed to open BIN file: %v", b.Type, b.Action, err)
+	}
+	defer file.Close()
+
+	starts := make([]netip.Addr, count)
+	for i := 0; i < count; i++ {
+		addr, err := readRowStart(file, baseAddr, rowSize, i, addrSize)
+		if err != nil {
+			return fmt.Errorf("❌ [type %s | action %s] failed to read record %d: %v", b.Type, b.Action, i, err)
+		}
+		starts[i] = addr
+	}
+
+	maxAddr := maxAddrFor(isIPv6)
+
+	for i, from := range starts {
+		to := maxAddr
+		if i+1 < len(starts) {
+			prev, err := prevAddr(starts[i+1])
+			if err != nil {
+				return fmt.Errorf("❌ [type %s | action %s] failed to compute range end: %v", b.Type, b.Action, err)
+			}
+			to = prev
+		}
+
+		record, err := db.Get_all(from.String())
+		if err != nil {
+			return fmt.Errorf("❌ [type %s | action %s] failed to decode record %d: %v", b.Type, b.Action, i, err)
+		}
+
+		name := entryName(b.EmitLevel, record)
+		if name == "" {
+			continue
+		}
+
+		entry, ok := entryMap[name]
+		if !ok {
+			entry = lib.NewEntry(name)
+			entryMap[name] = entry
+		}
+
+		prefixes, err := iprange.Split(from, to)
+		if err != nil {
+			return fmt.Errorf("❌ [type %s | action %s] failed to split range %s-%s: %v", b.Type, b.Action, from, to, err)
+		}
+		for _, prefix := range prefixes {
+			if err := entry.AddPrefix(prefix.String()); err != nil {
+				return fmt.Errorf("❌ [type %s | action %s] failed to add CIDR %s to %s: %v", b.Type, b.Action, prefix, name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func entryName(emitLevel string, record ip2location.IP2Locationrecord) string {
+	country := strings.ToUpper(strings.TrimSpace(record.Country_short))
+	if country == "" || country == "-" {
+		return ""
+	}
+
+	switch emitLevel {
+	case emitLevelRegion:
+		region := sanitizeComponent(record.Region)
+		if region == "" {
+			return country
+		}
+		return country + "-" + region
+	case emitLevelCity:
+		region := sanitizeComponent(record.Region)
+		city := sanitizeComponent(record.City)
+		switch {
+		case region == "" && city == "":
+			return country
+		case city == "":
+			return country + "-" + region
+		default:
+			return country + "-" + region + "-" + city
+		}
+	default:
+		return country
+	}
+}
+
+func sanitizeComponent(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" || s == "-" {
+		return ""
+	}
+	return strings.ReplaceAll(s, " ", "_")
+}
+
+type binHeader struct {
+	columnCount  int
+	ipv4Count    int
+	ipv4BaseAddr int
+	ipv6Count    int
+	ipv6BaseAddr int
+}
+
+// readBINHeader reads the fixed 64-byte IP2Location BIN header. Offsets
+// follow the documented IP2Location BIN file structure.
+func readBINHeader(uri string) (*binHeader, error) {
+	file, err := os.Open(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, 64)
+	if _, err := file.ReadAt(buf, 0); err != nil {
+		return nil, err
+	}
+
+	return &binHeader{
+		columnCount:  int(buf[1]),
+		ipv4Count:    int(binary.LittleEndian.Uint32(buf[5:9])),
+		ipv4BaseAddr: int(binary.LittleEndian.Uint32(buf[9:13])),
+		ipv6Count:    int(binary.LittleEndian.Uint32(buf[13:17])),
+		ipv6BaseAddr: int(binary.LittleEndian.Uint32(buf[17:21])),
+	}, nil
+}
+
+func readRowStart(file *os.File, baseAddr, rowSize, index, addrSize int) (netip.Addr, error) {
+	offset := int64(baseAddr-1) + int64(index)*int64(rowSize)
+	buf := make([]byte, addrSize)
+	if _, err := file.ReadAt(buf, offset); err != nil {
+		return netip.Addr{}, err
+	}
+
+	if addrSize == 4 {
+		// IP2Location stores IPv4 addresses little-endian on disk.
+		var be [4]byte
+		for i := 0; i < 4; i++ {
+			be[i] = buf[3-i]
+		}
+		addr, ok := netip.AddrFromSlice(be[:])
+		if !ok {
+			return netip.Addr{}, fmt.Errorf("invalid IPv4 address bytes")
+		}
+		return addr, nil
+	}
+
+	var be [16]byte
+	for i := 0; i < 16; i++ {
+		be[i] = buf[15-i]
+	}
+	addr, ok := netip.AddrFromSlice(be[:])
+	if !ok {
+		return netip.Addr{}, fmt.Errorf("invalid IPv6 address bytes")
+	}
+	return addr, nil
+}
+
+func prevAddr(addr netip.Addr) (netip.Addr, error) {
+	prev := addr.Prev()
+	if !prev.IsValid() {
+		return netip.Addr{}, fmt.Errorf("no address before %s", addr)
+	}
+	return prev, nil
+}
+
+func maxAddrFor(isIPv6 bool) netip.Addr {
+	if isIPv6 {
+		return netip.MustParseAddr("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff")
+	}
+	return netip.MustParseAddr("255.255.255.255")
+}
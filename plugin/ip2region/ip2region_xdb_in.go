@@ -0,0 +1,241 @@
+package ip2region
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/Loyalsoldier/geoip/lib"
+	"github.com/Loyalsoldier/geoip/lib/cndb"
+	"github.com/Loyalsoldier/geoip/lib/iprange"
+)
+
+const (
+	TypeIP2RegionXDBIn = "ip2regionXDB"
+	DescIP2RegionXDBIn = "Convert ip2region xdb database to other formats"
+)
+
+func init() {
+	lib.RegisterInputConfigCreator(TypeIP2RegionXDBIn, func(action lib.Action, data json.RawMessage) (lib.InputConverter, error) {
+		return newIP2RegionXDBIn(action, data)
+	})
+	lib.RegisterInputConverter(TypeIP2RegionXDBIn, &IP2RegionXDBIn{
+		Description: DescIP2RegionXDBIn,
+	})
+}
+
+func newIP2RegionXDBIn(action lib.Action, data json.RawMessage) (lib.InputConverter, error) {
+	var tmp struct {
+		URI                   string     `json:"uri"`
+		Want                  []string   `json:"wantedList"`
+		OnlyIPType            lib.IPType `json:"onlyIPType"`
+		ProvinceAsSubdivision bool       `json:"provinceAsSubdivision"`
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &tmp); err != nil {
+			return nil, err
+		}
+	}
+
+	if tmp.URI == "" {
+		return nil, fmt.Errorf("❌ [type %s | action %s] uri is required", TypeIP2RegionXDBIn, action)
+	}
+
+	wantList := make(map[string]bool)
+	for _, want := range tmp.Want {
+		if want = strings.ToUpper(strings.TrimSpace(want)); want != "" {
+			wantList[want] = true
+		}
+	}
+
+	return &IP2RegionXDBIn{
+		Type:                  TypeIP2RegionXDBIn,
+		Action:                action,
+		Description:           DescIP2RegionXDBIn,
+		URI:                   tmp.URI,
+		Want:                  wantList,
+		OnlyIPType:            tmp.OnlyIPType,
+		ProvinceAsSubdivision: tmp.ProvinceAsSubdivision,
+	}, nil
+}
+
+// IP2RegionXDBIn reads an ip2region v2 xdb file directly (not through the
+// search API, which is optimized for point lookups) so the whole file can
+// be converted to CIDR entries in a single linear pass over its index.
+type IP2RegionXDBIn struct {
+	Type                  string
+	Action                lib.Action
+	Description           string
+	URI                   string
+	Want                  map[string]bool
+	OnlyIPType            lib.IPType
+	ProvinceAsSubdivision bool
+}
+
+func (x *IP2RegionXDBIn) GetType() string {
+	return x.Type
+}
+
+func (x *IP2RegionXDBIn) GetAction() lib.Action {
+	return x.Action
+}
+
+func (x *IP2RegionXDBIn) GetDescription() string {
+	return x.Description
+}
+
+// xdb v2 is IPv4-only.
+func (x *IP2RegionXDBIn) Input(container lib.Container) (lib.Container, error) {
+	if x.OnlyIPType == lib.IPv6 {
+		return container, nil
+	}
+
+	content, err := os.ReadFile(x.URI)
+	if err != nil {
+		return nil, fmt.Errorf("❌ [type %s | action %s] failed to read xdb file: %v", x.Type, x.Action, err)
+	}
+
+	records, err := parseXDBIndex(content)
+	if err != nil {
+		return nil, fmt.Errorf("❌ [type %s | action %s] failed to parse xdb index: %v", x.Type, x.Action, err)
+	}
+
+	entryMap := make(map[string]*lib.Entry)
+	for _, rec := range records {
+		country, province := parseRegionString(rec.region)
+
+		code, ok := cndb.NormalizeCountry(country)
+		if !ok {
+			continue
+		}
+		names := []string{code}
+		if x.ProvinceAsSubdivision && code == "CN" {
+			if subdivision, ok := cndb.NormalizeProvince(province); ok {
+				names = append(names, subdivision)
+			}
+		}
+
+		prefixes, err := iprange.Split(rec.startIP, rec.endIP)
+		if err != nil {
+			return nil, fmt.Errorf("❌ [type %s | action %s] failed to split range %s-%s: %v", x.Type, x.Action, rec.startIP, rec.endIP, err)
+		}
+
+		for _, name := range names {
+			entry, ok := entryMap[name]
+			if !ok {
+				entry = lib.NewEntry(name)
+				entryMap[name] = entry
+			}
+			for _, prefix := range prefixes {
+				if err := entry.AddPrefix(prefix.String()); err != nil {
+					return nil, fmt.Errorf("❌ [type %s | action %s] failed to add CIDR %s to %s: %v", x.Type, x.Action, prefix, name, err)
+				}
+			}
+		}
+	}
+
+	for name, entry := range entryMap {
+		if len(x.Want) > 0 && !x.Want[name] {
+			continue
+		}
+
+		switch x.Action {
+		case lib.ActionAdd:
+			if err := container.Add(entry); err != nil {
+				return nil, fmt.Errorf("❌ [type %s | action %s] failed to add entry %s: %v", x.Type, x.Action, name, err)
+			}
+		case lib.ActionRemove:
+			if err := container.Remove(entry, lib.CaseRemoveEntry, lib.IgnoreIPv6); err != nil {
+				return nil, fmt.Errorf("❌ [type %s | action %s] failed to remove entry %s: %v", x.Type, x.Action, name, err)
+			}
+		}
+	}
+
+	return container, nil
+}
+
+type xdbRecord struct {
+	startIP netip.Addr
+	endIP   netip.Addr
+	region  string
+}
+
+// xdb v2 header reserves 256 bytes: Version (2B) + IndexPolicy (2B) +
+// CreatedAt (4B) + StartIndexPtr (4B) + EndIndexPtr (4B) + ... . It is
+// followed by a flat array of index entries (14 bytes each: 4B start IP +
+// 4B end IP + 2B data length + 4B data pointer), followed by the
+// variable-length data segment holding each entry's
+// "国家|区域|省份|城市|ISP" region string.
+const (
+	xdbHeaderSize         = 256
+	xdbStartIndexPtrField = 8
+	xdbEndIndexPtrField   = 12
+	xdbIndexSize          = 14
+	xdbIndexPtrField      = 10
+)
+
+func parseXDBIndex(content []byte) ([]xdbRecord, error) {
+	if len(content) < xdbHeaderSize+4 {
+		return nil, fmt.Errorf("file too small to be a valid xdb")
+	}
+
+	indexStart := int(binary.LittleEndian.Uint32(content[xdbStartIndexPtrField : xdbStartIndexPtrField+4]))
+	indexEnd := int(binary.LittleEndian.Uint32(content[xdbEndIndexPtrField : xdbEndIndexPtrField+4]))
+	if indexStart <= 0 || indexEnd <= indexStart || indexEnd > len(content) {
+		return nil, fmt.Errorf("invalid xdb index range")
+	}
+
+	count := (indexEnd - indexStart + 1) / xdbIndexSize
+
+	records := make([]xdbRecord, 0, count)
+	for i := 0; i < count; i++ {
+		offset := indexStart + i*xdbIndexSize
+		entry := content[offset : offset+xdbIndexSize]
+
+		startIP, ok := ipv4FromUint32(binary.LittleEndian.Uint32(entry[0:4]))
+		if !ok {
+			return nil, fmt.Errorf("invalid start IP in index entry %d", i)
+		}
+		endIP, ok := ipv4FromUint32(binary.LittleEndian.Uint32(entry[4:8]))
+		if !ok {
+			return nil, fmt.Errorf("invalid end IP in index entry %d", i)
+		}
+		dataLen := int(binary.LittleEndian.Uint16(entry[8:10]))
+		dataPtr := int(binary.LittleEndian.Uint32(entry[xdbIndexPtrField:14]))
+
+		if dataPtr+dataLen > len(content) {
+			return nil, fmt.Errorf("data segment out of bounds for index entry %d", i)
+		}
+
+		records = append(records, xdbRecord{
+			startIP: startIP,
+			endIP:   endIP,
+			region:  string(content[dataPtr : dataPtr+dataLen]),
+		})
+	}
+
+	return records, nil
+}
+
+func ipv4FromUint32(v uint32) (netip.Addr, bool) {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	return netip.AddrFromSlice(buf[:])
+}
+
+// parseRegionString splits an ip2region "国家|区域|省份|城市|ISP" string into
+// its country and province components.
+func parseRegionString(region string) (country, province string) {
+	parts := strings.Split(region, "|")
+	if len(parts) > 0 {
+		country = parts[0]
+	}
+	if len(parts) > 2 {
+		province = parts[2]
+	}
+	return country, province
+}
@@ -0,0 +1,69 @@
+package ip2region
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"testing"
+)
+
+func ipToXDBUint32(t *testing.T, ip string) uint32 {
+	t.Helper()
+	b := netip.MustParseAddr(ip).As4()
+	return binary.BigEndian.Uint32(b[:])
+}
+
+func TestParseXDBIndex(t *testing.T) {
+	wants := []struct {
+		startIP, endIP string
+		region         string
+	}{
+		{"1.2.3.0", "1.2.3.255", "CN|0|Beijing|Beijing|Telecom"},
+		{"8.8.8.0", "8.8.8.255", "US|0|California|Mountain View|Google"},
+	}
+
+	indexStart := xdbHeaderSize
+	indexEnd := indexStart + len(wants)*xdbIndexSize - 1
+
+	content := make([]byte, indexStart+len(wants)*xdbIndexSize)
+	// CreatedAt (bytes 4:8) holds a value that looks like a real Unix
+	// timestamp, much larger than len(content); if StartIndexPtr/
+	// EndIndexPtr were ever misread from here instead of bytes 8:16,
+	// parseXDBIndex must fail rather than silently succeed.
+	binary.LittleEndian.PutUint32(content[4:8], 1_700_000_000)
+	binary.LittleEndian.PutUint32(content[xdbStartIndexPtrField:xdbStartIndexPtrField+4], uint32(indexStart))
+	binary.LittleEndian.PutUint32(content[xdbEndIndexPtrField:xdbEndIndexPtrField+4], uint32(indexEnd))
+
+	for i, w := range wants {
+		offset := indexStart + i*xdbIndexSize
+		binary.LittleEndian.PutUint32(content[offset:offset+4], ipToXDBUint32(t, w.startIP))
+		binary.LittleEndian.PutUint32(content[offset+4:offset+8], ipToXDBUint32(t, w.endIP))
+
+		dataPtr := len(content)
+		content = append(content, []byte(w.region)...)
+		dataLen := len(content) - dataPtr
+
+		binary.LittleEndian.PutUint16(content[offset+8:offset+10], uint16(dataLen))
+		binary.LittleEndian.PutUint32(content[xdbIndexPtrField+offset:xdbIndexPtrField+offset+4], uint32(dataPtr))
+	}
+
+	records, err := parseXDBIndex(content)
+	if err != nil {
+		t.Fatalf("parseXDBIndex: unexpected error: %v", err)
+	}
+	if len(records) != len(wants) {
+		t.Fatalf("parseXDBIndex: got %d records, want %d", len(records), len(wants))
+	}
+
+	for i, w := range wants {
+		rec := records[i]
+		if rec.startIP.String() != w.startIP {
+			t.Errorf("record %d: startIP = %s, want %s", i, rec.startIP, w.startIP)
+		}
+		if rec.endIP.String() != w.endIP {
+			t.Errorf("record %d: endIP = %s, want %s", i, rec.endIP, w.endIP)
+		}
+		if rec.region != w.region {
+			t.Errorf("record %d: region = %q, want %q", i, rec.region, w.region)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package maxmind
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Loyalsoldier/geoip/lib"
+	"github.com/Loyalsoldier/geoip/lib/fetch"
+)
+
+const (
+	TypeMaxmindGeoLite2CitySubdivisionIn = "maxmindGeoLite2CitySubdivision"
+	DescMaxmindGeoLite2CitySubdivisionIn = "Convert MaxMind GeoLite2-City CSV to per-subdivision entries"
+)
+
+func init() {
+	lib.RegisterInputConfigCreator(TypeMaxmindGeoLite2CitySubdivisionIn, func(action lib.Action, data json.RawMessage) (lib.InputConverter, error) {
+		return newMaxmindGeoLite2CitySubdivisionIn(action, data)
+	})
+	lib.RegisterInputConverter(TypeMaxmindGeoLite2CitySubdivisionIn, &MaxmindGeoLite2CitySubdivisionIn{
+		Description: DescMaxmindGeoLite2CitySubdivisionIn,
+	})
+}
+
+func newMaxmindGeoLite2CitySubdivisionIn(action lib.Action, data json.RawMessage) (lib.InputConverter, error) {
+	var tmp struct {
+		BlocksIPv4URI string            `json:"blocksIPv4Uri"`
+		BlocksIPv6URI string            `json:"blocksIPv6Uri"`
+		LocationsURI  string            `json:"locationsUri"`
+		Want          []string          `json:"wantedList"`
+		OnlyIPType    lib.IPType        `json:"onlyIPType"`
+		CacheTTL      int               `json:"cacheTTL"`
+		HTTPHeaders   map[string]string `json:"httpHeaders"`
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &tmp); err != nil {
+			return nil, err
+		}
+	}
+
+	if tmp.LocationsURI == "" {
+		return nil, fmt.Errorf("❌ [type %s | action %s] locationsUri is required", TypeMaxmindGeoLite2CitySubdivisionIn, action)
+	}
+	if tmp.BlocksIPv4URI == "" && tmp.BlocksIPv6URI == "" {
+		return nil, fmt.Errorf("❌ [type %s | action %s] at least one of blocksIPv4Uri or blocksIPv6Uri is required", TypeMaxmindGeoLite2CitySubdivisionIn, action)
+	}
+
+	wantList := make(map[string]bool)
+	for _, want := range tmp.Want {
+		if want = strings.ToUpper(strings.TrimSpace(want)); want != "" {
+			wantList[want] = true
+		}
+	}
+
+	return &MaxmindGeoLite2CitySubdivisionIn{
+		Type:          TypeMaxmindGeoLite2CitySubdivisionIn,
+		Action:        action,
+		Description:   DescMaxmindGeoLite2CitySubdivisionIn,
+		BlocksIPv4URI: tmp.BlocksIPv4URI,
+		BlocksIPv6URI: tmp.BlocksIPv6URI,
+		LocationsURI:  tmp.LocationsURI,
+		Want:          wantList,
+		OnlyIPType:    tmp.OnlyIPType,
+		CacheTTL:      time.Duration(tmp.CacheTTL) * time.Second,
+		HTTPHeaders:   tmp.HTTPHeaders,
+	}, nil
+}
+
+// MaxmindGeoLite2CitySubdivisionIn reads the GeoLite2-City CSV distribution
+// (blocks + locations) and emits one lib.Entry per "CC-SUB" subdivision code,
+// e.g. US-CA, US-OR. Rows whose subdivision code is empty are skipped, since
+// the corresponding addresses are already covered by the country-level entry
+// produced by a country-level converter.
+type MaxmindGeoLite2CitySubdivisionIn struct {
+	Type          string
+	Action        lib.Action
+	Description   string
+	BlocksIPv4URI string
+	BlocksIPv6URI string
+	LocationsURI  string
+	Want          map[string]bool
+	OnlyIPType    lib.IPType
+	CacheTTL      time.Duration
+	HTTPHeaders   map[string]string
+}
+
+func (g *MaxmindGeoLite2CitySubdivisionIn) GetType() string {
+	return g.Type
+}
+
+func (g *MaxmindGeoLite2CitySubdivisionIn) GetAction() lib.Action {
+	return g.Action
+}
+
+func (g *MaxmindGeoLite2CitySubdivisionIn) GetDescription() string {
+	return g.Description
+}
+
+func (g *MaxmindGeoLite2CitySubdivisionIn) Input(container lib.Container) (lib.Container, error) {
+	// Locations file is small compared to the blocks file, so it's loaded
+	// fully into memory to join against the streamed blocks rows below.
+	geonameToSubdivision, err := g.loadLocations()
+	if err != nil {
+		return nil, err
+	}
+
+	entryMap := make(map[string]*lib.Entry)
+
+	if g.OnlyIPType != lib.IPv6 && g.BlocksIPv4URI != "" {
+		if err := g.streamBlocks(g.BlocksIPv4URI, geonameToSubdivision, entryMap); err != nil {
+			return nil, err
+		}
+	}
+	if g.OnlyIPType != lib.IPv4 && g.BlocksIPv6URI != "" {
+		if err := g.streamBlocks(g.BlocksIPv6URI, geonameToSubdivision, entryMap); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, entry := range entryMap {
+		if len(g.Want) > 0 && !g.Want[name] {
+			continue
+		}
+
+		switch g.Action {
+		case lib.ActionAdd:
+			if err := container.Add(entry); err != nil {
+				return nil, fmt.Errorf("❌ [type %s | action %s] failed to add subdivision %s: %v", g.Type, g.Action, name, err)
+			}
+		case lib.ActionRemove:
+			var ignoreIPType lib.IgnoreIPOption
+			switch g.OnlyIPType {
+			case lib.IPv4:
+				ignoreIPType = lib.IgnoreIPv6
+			case lib.IPv6:
+				ignoreIPType = lib.IgnoreIPv4
+			}
+			if err := container.Remove(entry, lib.CaseRemoveEntry, ignoreIPType); err != nil {
+				return nil, fmt.Errorf("❌ [type %s | action %s] failed to remove subdivision %s: %v", g.Type, g.Action, name, err)
+			}
+		}
+	}
+
+	return container, nil
+}
+
+// loadLocations builds a geoname_id -> "CC-SUB" lookup from the
+// GeoLite2-City-Locations-en.csv file. The file is small compared to the
+// blocks file, so it's fetched in full, which also lets it be a remote
+// http(s) URI cached on disk via lib/fetch.
+func (g *MaxmindGeoLite2CitySubdivisionIn) loadLocations() (map[string]string, error) {
+	content, err := fetch.Fetch(fetch.Options{
+		URI:      g.LocationsURI,
+		CacheTTL: g.CacheTTL,
+		Headers:  g.HTTPHeaders,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("❌ [type %s | action %s] failed to read locations file: %v", g.Type, g.Action, err)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("❌ [type %s | action %s] failed to read locations header: %v", g.Type, g.Action, err)
+	}
+	col := csvColumnIndex(header)
+
+	geonameIDIdx, ok1 := col["geoname_id"]
+	countryIdx, ok2 := col["country_iso_code"]
+	subdivisionIdx, ok3 := col["subdivision_1_iso_code"]
+	if !ok1 || !ok2 || !ok3 {
+		return nil, fmt.Errorf("❌ [type %s | action %s] locations file missing required columns", g.Type, g.Action)
+	}
+
+	geonameToSubdivision := make(map[string]string)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("❌ [type %s | action %s] failed to parse locations row: %v", g.Type, g.Action, err)
+		}
+
+		subdivision := strings.ToUpper(strings.TrimSpace(record[subdivisionIdx]))
+		if subdivision == "" {
+			continue
+		}
+		country := strings.ToUpper(strings.TrimSpace(record[countryIdx]))
+		if country == "" {
+			continue
+		}
+
+		geonameToSubdivision[record[geonameIDIdx]] = country + "-" + subdivision
+	}
+
+	return geonameToSubdivision, nil
+}
+
+// streamBlocks reads the blocks CSV row by row, joining each row's
+// geoname_id against geonameToSubdivision, so the full file is never held in
+// memory at once. Unlike LocationsURI, this intentionally reads uri as a
+// local file rather than through lib/fetch, since the blocks file is far
+// too large to buffer in memory whole; remote URIs are rejected up front
+// with an explicit error instead of failing on os.Open.
+func (g *MaxmindGeoLite2CitySubdivisionIn) streamBlocks(uri string, geonameToSubdivision map[string]string, entryMap map[string]*lib.Entry) error {
+	if fetch.IsRemote(uri) {
+		return fmt.Errorf("❌ [type %s | action %s] blocksIPv4Uri/blocksIPv6Uri must be a local file path, not %s: the blocks file is streamed to avoid buffering it in memory, which lib/fetch cannot do", g.Type, g.Action, uri)
+	}
+
+	file, err := os.Open(uri)
+	if err != nil {
+		return fmt.Errorf("❌ [type %s | action %s] failed to open blocks file %s: %v", g.Type, g.Action, uri, err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("❌ [type %s | action %s] failed to read blocks header: %v", g.Type, g.Action, err)
+	}
+	col := csvColumnIndex(header)
+
+	networkIdx, ok1 := col["network"]
+	geonameIdx, ok2 := col["geoname_id"]
+	if !ok1 || !ok2 {
+		return fmt.Errorf("❌ [type %s | action %s] blocks file missing required columns", g.Type, g.Action)
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("❌ [type %s | action %s] failed to parse blocks row: %v", g.Type, g.Action, err)
+		}
+
+		subdivision, ok := geonameToSubdivision[record[geonameIdx]]
+		if !ok {
+			// No subdivision for this geoname_id, fall back to country-only.
+			continue
+		}
+
+		entry, ok := entryMap[subdivision]
+		if !ok {
+			entry = lib.NewEntry(subdivision)
+			entryMap[subdivision] = entry
+		}
+		if err := entry.AddPrefix(record[networkIdx]); err != nil {
+			return fmt.Errorf("❌ [type %s | action %s] failed to add CIDR %s to %s: %v", g.Type, g.Action, record[networkIdx], subdivision, err)
+		}
+	}
+
+	return nil
+}
+
+func csvColumnIndex(header []string) map[string]int {
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		col[strings.TrimSpace(name)] = i
+	}
+	return col
+}
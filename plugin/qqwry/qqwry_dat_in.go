@@ -0,0 +1,301 @@
+package qqwry
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"github.com/Loyalsoldier/geoip/lib"
+	"github.com/Loyalsoldier/geoip/lib/cndb"
+	"github.com/Loyalsoldier/geoip/lib/iprange"
+)
+
+const (
+	TypeQQWryDATIn = "qqwryDAT"
+	DescQQWryDATIn = "Convert QQWry .dat database to other formats"
+)
+
+func init() {
+	lib.RegisterInputConfigCreator(TypeQQWryDATIn, func(action lib.Action, data json.RawMessage) (lib.InputConverter, error) {
+		return newQQWryDATIn(action, data)
+	})
+	lib.RegisterInputConverter(TypeQQWryDATIn, &QQWryDATIn{
+		Description: DescQQWryDATIn,
+	})
+}
+
+func newQQWryDATIn(action lib.Action, data json.RawMessage) (lib.InputConverter, error) {
+	var tmp struct {
+		URI                   string     `json:"uri"`
+		Want                  []string   `json:"wantedList"`
+		OnlyIPType            lib.IPType `json:"onlyIPType"`
+		ProvinceAsSubdivision bool       `json:"provinceAsSubdivision"`
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &tmp); err != nil {
+			return nil, err
+		}
+	}
+
+	if tmp.URI == "" {
+		return nil, fmt.Errorf("❌ [type %s | action %s] uri is required", TypeQQWryDATIn, action)
+	}
+
+	wantList := make(map[string]bool)
+	for _, want := range tmp.Want {
+		if want = strings.ToUpper(strings.TrimSpace(want)); want != "" {
+			wantList[want] = true
+		}
+	}
+
+	return &QQWryDATIn{
+		Type:                  TypeQQWryDATIn,
+		Action:                action,
+		Description:           DescQQWryDATIn,
+		URI:                   tmp.URI,
+		Want:                  wantList,
+		OnlyIPType:            tmp.OnlyIPType,
+		ProvinceAsSubdivision: tmp.ProvinceAsSubdivision,
+	}, nil
+}
+
+// QQWryDATIn reads a classic QQWry.dat database (GBK-encoded, IPv4-only)
+// and emits one entry per normalized country code.
+type QQWryDATIn struct {
+	Type                  string
+	Action                lib.Action
+	Description           string
+	URI                   string
+	Want                  map[string]bool
+	OnlyIPType            lib.IPType
+	ProvinceAsSubdivision bool
+}
+
+func (q *QQWryDATIn) GetType() string {
+	return q.Type
+}
+
+func (q *QQWryDATIn) GetAction() lib.Action {
+	return q.Action
+}
+
+func (q *QQWryDATIn) GetDescription() string {
+	return q.Description
+}
+
+func (q *QQWryDATIn) Input(container lib.Container) (lib.Container, error) {
+	if q.OnlyIPType == lib.IPv6 {
+		return container, nil
+	}
+
+	content, err := os.ReadFile(q.URI)
+	if err != nil {
+		return nil, fmt.Errorf("❌ [type %s | action %s] failed to read QQWry file: %v", q.Type, q.Action, err)
+	}
+
+	db, err := newQQWryDB(content)
+	if err != nil {
+		return nil, fmt.Errorf("❌ [type %s | action %s] failed to parse QQWry file: %v", q.Type, q.Action, err)
+	}
+
+	entryMap := make(map[string]*lib.Entry)
+	for i := 0; i < db.recordCount(); i++ {
+		startIP, endIP, countryRaw, areaRaw, err := db.record(i)
+		if err != nil {
+			return nil, fmt.Errorf("❌ [type %s | action %s] failed to read record %d: %v", q.Type, q.Action, i, err)
+		}
+
+		code, ok := cndb.NormalizeCountry(countryRaw)
+		if !ok {
+			continue
+		}
+		names := []string{code}
+		if q.ProvinceAsSubdivision && code == "CN" {
+			if subdivision, ok := cndb.NormalizeProvince(areaRaw); ok {
+				names = append(names, subdivision)
+			}
+		}
+
+		prefixes, err := iprange.Split(startIP, endIP)
+		if err != nil {
+			return nil, fmt.Errorf("❌ [type %s | action %s] failed to split range %s-%s: %v", q.Type, q.Action, startIP, endIP, err)
+		}
+
+		for _, name := range names {
+			entry, ok := entryMap[name]
+			if !ok {
+				entry = lib.NewEntry(name)
+				entryMap[name] = entry
+			}
+			for _, prefix := range prefixes {
+				if err := entry.AddPrefix(prefix.String()); err != nil {
+					return nil, fmt.Errorf("❌ [type %s | action %s] failed to add CIDR %s to %s: %v", q.Type, q.Action, prefix, name, err)
+				}
+			}
+		}
+	}
+
+	for name, entry := range entryMap {
+		if len(q.Want) > 0 && !q.Want[name] {
+			continue
+		}
+
+		switch q.Action {
+		case lib.ActionAdd:
+			if err := container.Add(entry); err != nil {
+				return nil, fmt.Errorf("❌ [type %s | action %s] failed to add entry %s: %v", q.Type, q.Action, name, err)
+			}
+		case lib.ActionRemove:
+			if err := container.Remove(entry, lib.CaseRemoveEntry, lib.IgnoreIPv6); err != nil {
+				return nil, fmt.Errorf("❌ [type %s | action %s] failed to remove entry %s: %v", q.Type, q.Action, name, err)
+			}
+		}
+	}
+
+	return container, nil
+}
+
+// qqwryDB wraps the raw .dat bytes. The format is a flat, IP-sorted index
+// of 7-byte entries (4B start IP + 3B record offset) between two header
+// pointers, where each pointed-to record gives the range's end IP followed
+// by a (possibly redirected) country/area string pair.
+type qqwryDB struct {
+	data       []byte
+	indexStart uint32
+	indexEnd   uint32
+}
+
+const qqwryIndexEntrySize = 7
+
+func newQQWryDB(data []byte) (*qqwryDB, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("file too small to be a valid QQWry database")
+	}
+	indexStart := binary.LittleEndian.Uint32(data[0:4])
+	indexEnd := binary.LittleEndian.Uint32(data[4:8])
+	if indexEnd < indexStart || int(indexEnd)+qqwryIndexEntrySize > len(data) {
+		return nil, fmt.Errorf("invalid QQWry index range")
+	}
+	return &qqwryDB{data: data, indexStart: indexStart, indexEnd: indexEnd}, nil
+}
+
+func (db *qqwryDB) recordCount() int {
+	return int((db.indexEnd-db.indexStart)/qqwryIndexEntrySize) + 1
+}
+
+func (db *qqwryDB) record(i int) (startIP, endIP netip.Addr, country, area string, err error) {
+	indexOffset := int(db.indexStart) + i*qqwryIndexEntrySize
+	if indexOffset+qqwryIndexEntrySize > len(db.data) {
+		return startIP, endIP, "", "", fmt.Errorf("index entry %d out of bounds", i)
+	}
+
+	startIP, ok := ipv4FromLE(db.data[indexOffset : indexOffset+4])
+	if !ok {
+		return startIP, endIP, "", "", fmt.Errorf("invalid start IP for entry %d", i)
+	}
+	recordOffset := uint32From3Bytes(db.data[indexOffset+4 : indexOffset+7])
+
+	if int(recordOffset)+4 > len(db.data) {
+		return startIP, endIP, "", "", fmt.Errorf("record offset out of bounds for entry %d", i)
+	}
+	endIP, ok = ipv4FromLE(db.data[recordOffset : recordOffset+4])
+	if !ok {
+		return startIP, endIP, "", "", fmt.Errorf("invalid end IP for entry %d", i)
+	}
+
+	country, area, err = db.readLocation(recordOffset + 4)
+	return startIP, endIP, country, area, err
+}
+
+// readLocation decodes the country and area strings starting at offset,
+// following QQWry's redirect modes: 0x01 redirects both fields to another
+// offset, 0x02 redirects only the country field while the area field
+// follows inline.
+func (db *qqwryDB) readLocation(offset uint32) (country, area string, err error) {
+	if int(offset) >= len(db.data) {
+		return "", "", fmt.Errorf("location offset out of bounds")
+	}
+
+	mode := db.data[offset]
+	switch mode {
+	case 0x01:
+		target := uint32From3Bytes(db.data[offset+1 : offset+4])
+		return db.readLocation(target)
+	case 0x02:
+		target := uint32From3Bytes(db.data[offset+1 : offset+4])
+		country, err = db.readRedirectedString(target)
+		if err != nil {
+			return "", "", err
+		}
+		area, err = db.readAreaString(offset + 4)
+		return country, area, err
+	default:
+		country, next, err := db.readCString(offset)
+		if err != nil {
+			return "", "", err
+		}
+		area, err = db.readAreaString(next)
+		return country, area, err
+	}
+}
+
+func (db *qqwryDB) readRedirectedString(offset uint32) (string, error) {
+	if int(offset) >= len(db.data) {
+		return "", fmt.Errorf("redirected string offset out of bounds")
+	}
+	if db.data[offset] == 0x02 {
+		target := uint32From3Bytes(db.data[offset+1 : offset+4])
+		return db.readRedirectedString(target)
+	}
+	s, _, err := db.readCString(offset)
+	return s, err
+}
+
+func (db *qqwryDB) readAreaString(offset uint32) (string, error) {
+	if int(offset) >= len(db.data) {
+		return "", nil
+	}
+	mode := db.data[offset]
+	if mode == 0x01 || mode == 0x02 {
+		target := uint32From3Bytes(db.data[offset+1 : offset+4])
+		return db.readRedirectedString(target)
+	}
+	s, _, err := db.readCString(offset)
+	return s, err
+}
+
+// readCString reads a NUL-terminated GBK string and returns its decoded
+// value plus the offset immediately following the terminator.
+func (db *qqwryDB) readCString(offset uint32) (string, uint32, error) {
+	end := offset
+	for int(end) < len(db.data) && db.data[end] != 0 {
+		end++
+	}
+	if int(end) >= len(db.data) {
+		return "", 0, fmt.Errorf("unterminated string at offset %d", offset)
+	}
+
+	decoded, err := simplifiedchinese.GBK.NewDecoder().String(string(db.data[offset:end]))
+	if err != nil {
+		return "", 0, err
+	}
+	return decoded, end + 1, nil
+}
+
+func ipv4FromLE(b []byte) (netip.Addr, bool) {
+	var be [4]byte
+	for i := 0; i < 4; i++ {
+		be[i] = b[3-i]
+	}
+	return netip.AddrFromSlice(be[:])
+}
+
+func uint32From3Bytes(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
@@ -0,0 +1,99 @@
+package qqwry
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"testing"
+)
+
+func ipToQQWryLEBytes(t *testing.T, ip string) []byte {
+	t.Helper()
+	b := netip.MustParseAddr(ip).As4()
+	v := binary.BigEndian.Uint32(b[:])
+	buf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buf, v)
+	return buf
+}
+
+func put3ByteOffset(buf []byte, offset int, v uint32) {
+	buf[offset] = byte(v)
+	buf[offset+1] = byte(v >> 8)
+	buf[offset+2] = byte(v >> 16)
+}
+
+// buildQQWryFile assembles a minimal, synthetic QQWry .dat file with two
+// index entries: one with inline country/area strings, and one whose
+// country string is redirected (mode 0x02) to a string stored elsewhere in
+// the file, exercising the two decode paths readLocation has to handle.
+func buildQQWryFile(t *testing.T) []byte {
+	t.Helper()
+
+	const indexStart = 8
+	const entries = 2
+	indexEnd := indexStart + (entries-1)*qqwryIndexEntrySize
+
+	buf := make([]byte, indexStart+entries*qqwryIndexEntrySize)
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(indexStart))
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(indexEnd))
+
+	// Record 0: inline country + area.
+	copy(buf[indexStart:indexStart+4], ipToQQWryLEBytes(t, "111.11.11.0"))
+	record0Offset := len(buf)
+	put3ByteOffset(buf, indexStart+4, uint32(record0Offset))
+	buf = append(buf, ipToQQWryLEBytes(t, "111.11.11.255")...)
+	buf = append(buf, []byte("CN\x00Beijing\x00")...)
+
+	// Record 1: country redirected (mode 0x02) to a string appended later,
+	// area inline.
+	entry1Offset := indexStart + qqwryIndexEntrySize
+	copy(buf[entry1Offset:entry1Offset+4], ipToQQWryLEBytes(t, "8.8.8.0"))
+	record1Offset := len(buf)
+	put3ByteOffset(buf, entry1Offset+4, uint32(record1Offset))
+	buf = append(buf, ipToQQWryLEBytes(t, "8.8.8.255")...)
+
+	// mode byte (0x02) + 3-byte placeholder for the redirected country
+	// string's offset, fixed up below once that offset is known.
+	locationOffset := len(buf)
+	buf = append(buf, 0x02, 0, 0, 0)
+	buf = append(buf, []byte("California\x00")...)
+
+	countryOffset := len(buf)
+	buf = append(buf, []byte("US\x00")...)
+	put3ByteOffset(buf, locationOffset+1, uint32(countryOffset))
+
+	return buf
+}
+
+func TestNewQQWryDBRecord(t *testing.T) {
+	data := buildQQWryFile(t)
+
+	db, err := newQQWryDB(data)
+	if err != nil {
+		t.Fatalf("newQQWryDB: unexpected error: %v", err)
+	}
+	if db.recordCount() != 2 {
+		t.Fatalf("recordCount() = %d, want 2", db.recordCount())
+	}
+
+	startIP, endIP, country, area, err := db.record(0)
+	if err != nil {
+		t.Fatalf("record(0): unexpected error: %v", err)
+	}
+	if startIP.String() != "111.11.11.0" || endIP.String() != "111.11.11.255" {
+		t.Errorf("record(0): range = %s-%s, want 111.11.11.0-111.11.11.255", startIP, endIP)
+	}
+	if country != "CN" || area != "Beijing" {
+		t.Errorf("record(0): country/area = %q/%q, want CN/Beijing", country, area)
+	}
+
+	startIP, endIP, country, area, err = db.record(1)
+	if err != nil {
+		t.Fatalf("record(1): unexpected error: %v", err)
+	}
+	if startIP.String() != "8.8.8.0" || endIP.String() != "8.8.8.255" {
+		t.Errorf("record(1): range = %s-%s, want 8.8.8.0-8.8.8.255", startIP, endIP)
+	}
+	if country != "US" || area != "California" {
+		t.Errorf("record(1): redirected country/area = %q/%q, want US/California", country, area)
+	}
+}
@@ -3,10 +3,11 @@ package special
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
+	"time"
 
 	"github.com/Loyalsoldier/geoip/lib"
+	"github.com/Loyalsoldier/geoip/lib/fetch"
 )
 
 const (
@@ -25,9 +26,12 @@ func init() {
 
 func newRegionMappingIn(action lib.Action, data json.RawMessage) (lib.InputConverter, error) {
 	var tmp struct {
-		URI        string     `json:"uri"`
-		Want       []string   `json:"wantedList"`
-		OnlyIPType lib.IPType `json:"onlyIPType"`
+		URI          string            `json:"uri"`
+		Want         []string          `json:"wantedList"`
+		OnlyIPType   lib.IPType        `json:"onlyIPType"`
+		InverseMatch bool              `json:"inverseMatch"`
+		CacheTTL     int               `json:"cacheTTL"`
+		HTTPHeaders  map[string]string `json:"httpHeaders"`
 	}
 
 	if len(data) > 0 {
@@ -49,22 +53,28 @@ func newRegionMappingIn(action lib.Action, data json.RawMessage) (lib.InputConve
 	}
 
 	return &RegionMappingIn{
-		Type:        TypeRegionMappingIn,
-		Action:      action,
-		Description: DescRegionMappingIn,
-		URI:         tmp.URI,
-		Want:        wantList,
-		OnlyIPType:  tmp.OnlyIPType,
+		Type:         TypeRegionMappingIn,
+		Action:       action,
+		Description:  DescRegionMappingIn,
+		URI:          tmp.URI,
+		Want:         wantList,
+		OnlyIPType:   tmp.OnlyIPType,
+		InverseMatch: tmp.InverseMatch,
+		CacheTTL:     time.Duration(tmp.CacheTTL) * time.Second,
+		HTTPHeaders:  tmp.HTTPHeaders,
 	}, nil
 }
 
 type RegionMappingIn struct {
-	Type        string
-	Action      lib.Action
-	Description string
-	URI         string
-	Want        map[string]bool
-	OnlyIPType  lib.IPType
+	Type         string
+	Action       lib.Action
+	Description  string
+	URI          string
+	Want         map[string]bool
+	OnlyIPType   lib.IPType
+	InverseMatch bool
+	CacheTTL     time.Duration
+	HTTPHeaders  map[string]string
 }
 
 func (r *RegionMappingIn) GetType() string {
@@ -80,8 +90,13 @@ func (r *RegionMappingIn) GetDescription() string {
 }
 
 func (r *RegionMappingIn) Input(container lib.Container) (lib.Container, error) {
-	// Read region mapping file
-	content, err := os.ReadFile(r.URI)
+	// Read region mapping file, fetching it over http(s) and caching on disk
+	// when URI is a remote URL
+	content, err := fetch.Fetch(fetch.Options{
+		URI:      r.URI,
+		CacheTTL: r.CacheTTL,
+		Headers:  r.HTTPHeaders,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("❌ [type %s | action %s] failed to read region mapping file: %v", r.Type, r.Action, err)
 	}
@@ -98,15 +113,18 @@ func (r *RegionMappingIn) Input(container lib.Container) (lib.Container, error)
 		entryMap[entry.GetName()] = entry
 	}
 
-	// Process each region
-	for regionName, countryCodes := range regionMapping {
-		regionName = strings.ToUpper(strings.TrimSpace(regionName))
-		
-		// Check if this region is wanted
-		if len(r.Want) > 0 && !r.Want[regionName] {
-			continue
-		}
+	flattened, err := r.expandHierarchy(regionMapping)
+	if err != nil {
+		return nil, err
+	}
 
+	regions, err := r.resolveRegions(flattened)
+	if err != nil {
+		return nil, err
+	}
+
+	// Process each region
+	for regionName, countryCodes := range regions {
 		// Create the region entry
 		regionEntry := lib.NewEntry(regionName)
 
@@ -163,4 +181,151 @@ func (r *RegionMappingIn) Input(container lib.Container) (lib.Container, error)
 	}
 
 	return container, nil
-}
\ No newline at end of file
+}
+
+// resolveRegions turns the flattened region mapping returned by
+// expandHierarchy into the set of regions to actually produce. With
+// InverseMatch unset, it's just regionMapping filtered
+// down to the wanted region names. With InverseMatch set, each wanted name
+// must refer to a region already defined in the file; for each one, a region
+// named "NOT_"+name is produced, containing every country appearing anywhere
+// in the file except the ones listed under that region.
+func (r *RegionMappingIn) resolveRegions(regionMapping map[string][]string) (map[string][]string, error) {
+	normalized := make(map[string][]string, len(regionMapping))
+	for regionName, countryCodes := range regionMapping {
+		normalized[strings.ToUpper(strings.TrimSpace(regionName))] = countryCodes
+	}
+
+	if !r.InverseMatch {
+		regions := make(map[string][]string, len(normalized))
+		for regionName, countryCodes := range normalized {
+			if len(r.Want) > 0 && !r.Want[regionName] {
+				continue
+			}
+			regions[regionName] = countryCodes
+		}
+		return regions, nil
+	}
+
+	if len(r.Want) == 0 {
+		return nil, fmt.Errorf("❌ [type %s | action %s] inverseMatch requires a non-empty wantedList", r.Type, r.Action)
+	}
+
+	universe := make(map[string]bool)
+	for _, countryCodes := range normalized {
+		for _, countryCode := range countryCodes {
+			if countryCode = strings.ToUpper(strings.TrimSpace(countryCode)); countryCode != "" {
+				universe[countryCode] = true
+			}
+		}
+	}
+
+	regions := make(map[string][]string, len(r.Want))
+	for wanted := range r.Want {
+		countryCodes, ok := normalized[wanted]
+		if !ok {
+			return nil, fmt.Errorf("❌ [type %s | action %s] inverseMatch: region %s not found in mapping file", r.Type, r.Action, wanted)
+		}
+
+		excluded := make(map[string]bool, len(countryCodes))
+		for _, countryCode := range countryCodes {
+			excluded[strings.ToUpper(strings.TrimSpace(countryCode))] = true
+		}
+
+		var complement []string
+		for countryCode := range universe {
+			if !excluded[countryCode] {
+				complement = append(complement, countryCode)
+			}
+		}
+
+		regions["NOT_"+wanted] = complement
+	}
+
+	return regions, nil
+}
+
+// expandHierarchy resolves a regionMapping file that references other region
+// names within itself (e.g. "EMEA": ["EUROPE", "MEA"]) down to flat country
+// (or subdivision) code lists. A leading "-" on an item subtracts the
+// referenced country or region from the result, e.g.
+// "EUROPE_EXCL_UK": ["EUROPE", "-GB", "-IE"].
+//
+// Each region is resolved via memoized depth-first recursion, so leaf-level
+// sets are materialized first and folded upward into their parents in
+// topological order; a region still being resolved when revisited indicates
+// a cycle, which is rejected with the offending region name.
+func (r *RegionMappingIn) expandHierarchy(regionMapping map[string][]string) (map[string][]string, error) {
+	normalized := make(map[string][]string, len(regionMapping))
+	for name, items := range regionMapping {
+		normalized[strings.ToUpper(strings.TrimSpace(name))] = items
+	}
+
+	resolved := make(map[string][]string, len(normalized))
+	inProgress := make(map[string]bool)
+
+	var resolve func(name string) ([]string, error)
+	resolve = func(name string) ([]string, error) {
+		if set, ok := resolved[name]; ok {
+			return set, nil
+		}
+		if inProgress[name] {
+			return nil, fmt.Errorf("❌ [type %s | action %s] cycle detected in region mapping at %s", r.Type, r.Action, name)
+		}
+		items := normalized[name]
+
+		inProgress[name] = true
+		set := make(map[string]bool)
+		var subtract []string
+		for _, raw := range items {
+			item := strings.TrimSpace(raw)
+			negate := strings.HasPrefix(item, "-")
+			if negate {
+				item = item[1:]
+			}
+			item = strings.ToUpper(strings.TrimSpace(item))
+			if item == "" {
+				continue
+			}
+
+			expanded := []string{item}
+			if _, isRegion := normalized[item]; isRegion {
+				sub, err := resolve(item)
+				if err != nil {
+					return nil, err
+				}
+				expanded = sub
+			}
+
+			if negate {
+				subtract = append(subtract, expanded...)
+			} else {
+				for _, code := range expanded {
+					set[code] = true
+				}
+			}
+		}
+		for _, code := range subtract {
+			delete(set, code)
+		}
+		delete(inProgress, name)
+
+		result := make([]string, 0, len(set))
+		for code := range set {
+			result = append(result, code)
+		}
+		resolved[name] = result
+		return result, nil
+	}
+
+	out := make(map[string][]string, len(normalized))
+	for name := range normalized {
+		set, err := resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = set
+	}
+
+	return out, nil
+}
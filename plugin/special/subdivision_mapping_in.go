@@ -0,0 +1,40 @@
+package special
+
+import (
+	"encoding/json"
+
+	"github.com/Loyalsoldier/geoip/lib"
+)
+
+const (
+	TypeSubdivisionMappingIn = "subdivisionMapping"
+	DescSubdivisionMappingIn = "Convert subdivision (ISO 3166-2) mapping to other formats"
+)
+
+func init() {
+	lib.RegisterInputConfigCreator(TypeSubdivisionMappingIn, func(action lib.Action, data json.RawMessage) (lib.InputConverter, error) {
+		return newSubdivisionMappingIn(action, data)
+	})
+	lib.RegisterInputConverter(TypeSubdivisionMappingIn, &RegionMappingIn{
+		Description: DescSubdivisionMappingIn,
+	})
+}
+
+// newSubdivisionMappingIn builds the subdivisionMapping converter, which
+// groups entries keyed by subdivision code (e.g. US-CA, US-OR) into
+// aggregated buckets (e.g. US-WEST), the same way RegionMappingIn groups
+// country entries into regions. It returns a *RegionMappingIn with its Type
+// and Description replaced, sharing RegionMappingIn's Input implementation
+// since both merge container entries by name.
+func newSubdivisionMappingIn(action lib.Action, data json.RawMessage) (lib.InputConverter, error) {
+	in, err := newRegionMappingIn(action, data)
+	if err != nil {
+		return nil, err
+	}
+
+	regionMappingIn := in.(*RegionMappingIn)
+	regionMappingIn.Type = TypeSubdivisionMappingIn
+	regionMappingIn.Description = DescSubdivisionMappingIn
+
+	return regionMappingIn, nil
+}